@@ -0,0 +1,185 @@
+package ipx
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+)
+
+// ErrNoFreeSubnet is returned by FindAvailableSubnet(s) when parent has no
+// room left for a block of the requested size.
+var ErrNoFreeSubnet = errors.New("no free subnet available")
+
+// FindAvailableSubnet returns the lowest-addressed CIDR of length newPrefix
+// inside parent that does not overlap any entry in used.
+func FindAvailableSubnet(parent *net.IPNet, used []*net.IPNet, newPrefix int) (*net.IPNet, error) {
+	subnets, err := FindAvailableSubnets(parent, used, newPrefix, 1)
+	if err != nil {
+		return nil, err
+	}
+	return subnets[0], nil
+}
+
+// FindAvailableSubnets is like FindAvailableSubnet but returns up to count
+// non-overlapping blocks, in increasing address order.
+func FindAvailableSubnets(parent *net.IPNet, used []*net.IPNet, newPrefix, count int) ([]*net.IPNet, error) {
+	ones, bits := parent.Mask.Size()
+	if newPrefix < ones || newPrefix > bits {
+		return nil, fmt.Errorf("requested prefix /%d is not a subnet of /%d", newPrefix, ones)
+	}
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive, got %d", count)
+	}
+
+	if parent.IP.To4() != nil {
+		return findAvailable4(parent, used, newPrefix, count)
+	}
+	return findAvailable6(parent, used, newPrefix, count)
+}
+
+type span4 struct{ start, end uint64 }
+
+func findAvailable4(parent *net.IPNet, used []*net.IPNet, newPrefix, count int) ([]*net.IPNet, error) {
+	blockSize := uint64(1) << uint(32-newPrefix)
+	parentStart := uint64(to32(parent.IP))
+	parentEnd := uint64(to32(Broadcast(parent)))
+
+	var spans []span4
+	for _, u := range used {
+		if u.IP.To4() == nil {
+			continue
+		}
+		s, e := uint64(to32(u.IP)), uint64(to32(Broadcast(u)))
+		if e < parentStart || s > parentEnd {
+			continue // entirely outside parent
+		}
+		if s < parentStart {
+			s = parentStart
+		}
+		if e > parentEnd {
+			e = parentEnd
+		}
+		spans = append(spans, span4{s, e})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	merged := spans[:0]
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end+1 {
+			if s.end > merged[n-1].end {
+				merged[n-1].end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	cursor := (parentStart + blockSize - 1) &^ (blockSize - 1)
+	var result []*net.IPNet
+	tryEmit := func() bool {
+		out := &net.IPNet{IP: make(net.IP, 4), Mask: net.CIDRMask(newPrefix, 32)}
+		from32(uint32(cursor), out.IP)
+		result = append(result, out)
+		cursor += blockSize
+		return len(result) == count
+	}
+
+	for _, s := range merged {
+		for cursor+blockSize-1 < s.start {
+			if tryEmit() {
+				return result, nil
+			}
+		}
+		if cursor <= s.end {
+			cursor = (s.end + 1 + blockSize - 1) &^ (blockSize - 1)
+		}
+	}
+	for cursor+blockSize-1 <= parentEnd {
+		if tryEmit() {
+			return result, nil
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNoFreeSubnet
+	}
+	return result, nil
+}
+
+type span6 struct{ start, end uint128 }
+
+func findAvailable6(parent *net.IPNet, used []*net.IPNet, newPrefix, count int) ([]*net.IPNet, error) {
+	parentStart := to128(parent.IP)
+	parentEnd := to128(Broadcast(parent))
+
+	var spans []span6
+	for _, u := range used {
+		if u.IP.To4() != nil {
+			continue
+		}
+		s, e := to128(u.IP), to128(Broadcast(u))
+		if e.Cmp(parentStart) == -1 || s.Cmp(parentEnd) == 1 {
+			continue // entirely outside parent
+		}
+		if s.Cmp(parentStart) == -1 {
+			s = parentStart
+		}
+		if e.Cmp(parentEnd) == 1 {
+			e = parentEnd
+		}
+		spans = append(spans, span6{s, e})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start.Cmp(spans[j].start) == -1 })
+
+	merged := spans[:0]
+	for _, s := range spans {
+		if n := len(merged); n > 0 && s.start.Cmp(merged[n-1].end.Plus(uint128{0, 1})) != 1 {
+			if s.end.Cmp(merged[n-1].end) == 1 {
+				merged[n-1].end = s.end
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+
+	cursor := alignUp128(parentStart, newPrefix)
+	var result []*net.IPNet
+	tryEmit := func() bool {
+		out := &net.IPNet{IP: make(net.IP, 16), Mask: net.CIDRMask(newPrefix, 128)}
+		from128(cursor, out.IP)
+		result = append(result, out)
+		cursor = cursor.Plus(uint128{0, 1}.Lsh(uint(128 - newPrefix)))
+		return len(result) == count
+	}
+	blockEnd := func(start uint128) uint128 {
+		return start.Plus(uint128{0, 1}.Lsh(uint(128 - newPrefix))).Minus(uint128{0, 1})
+	}
+
+	for _, s := range merged {
+		for blockEnd(cursor).Cmp(s.start) == -1 {
+			if tryEmit() {
+				return result, nil
+			}
+		}
+		if cursor.Cmp(s.end) != 1 {
+			cursor = alignUp128(s.end.Plus(uint128{0, 1}), newPrefix)
+		}
+	}
+	for blockEnd(cursor).Cmp(parentEnd) != 1 {
+		if tryEmit() {
+			return result, nil
+		}
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNoFreeSubnet
+	}
+	return result, nil
+}
+
+// alignUp128 rounds addr up to the nearest newPrefix-aligned block boundary.
+func alignUp128(addr uint128, newPrefix int) uint128 {
+	blockSize := uint128{0, 1}.Lsh(uint(128 - newPrefix))
+	return addr.Plus(blockSize).Minus(uint128{0, 1}).And(mask128(newPrefix))
+}
@@ -0,0 +1,77 @@
+package ipx
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestFindAvailableSubnet(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("10.0.0.0/24")
+	_, used1, _ := net.ParseCIDR("10.0.0.0/26")
+	_, used2, _ := net.ParseCIDR("10.0.0.128/26")
+
+	got, err := FindAvailableSubnet(parent, []*net.IPNet{used1, used2}, 26)
+	if err != nil {
+		t.Fatalf("FindAvailableSubnet returned error: %v", err)
+	}
+	if want := "10.0.0.64/26"; got.String() != want {
+		t.Errorf("FindAvailableSubnet = %s, want %s", got, want)
+	}
+}
+
+func TestFindAvailableSubnetExhausted(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("10.0.0.0/24")
+	_, used, _ := net.ParseCIDR("10.0.0.0/24")
+
+	_, err := FindAvailableSubnet(parent, []*net.IPNet{used}, 25)
+	if !errors.Is(err, ErrNoFreeSubnet) {
+		t.Errorf("FindAvailableSubnet = %v, want ErrNoFreeSubnet", err)
+	}
+}
+
+func TestFindAvailableSubnets(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("10.0.0.0/24")
+	_, used, _ := net.ParseCIDR("10.0.0.0/26")
+
+	got, err := FindAvailableSubnets(parent, []*net.IPNet{used}, 26, 3)
+	if err != nil {
+		t.Fatalf("FindAvailableSubnets returned error: %v", err)
+	}
+	want := []string{"10.0.0.64/26", "10.0.0.128/26", "10.0.0.192/26"}
+	if len(got) != len(want) {
+		t.Fatalf("FindAvailableSubnets = %v, want %v", got, want)
+	}
+	for i, n := range got {
+		if n.String() != want[i] {
+			t.Errorf("FindAvailableSubnets()[%d] = %s, want %s", i, n, want[i])
+		}
+	}
+}
+
+func TestFindAvailableSubnetOverlappingUsed(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("10.0.0.0/24")
+	_, used1, _ := net.ParseCIDR("10.0.0.0/25")
+	_, used2, _ := net.ParseCIDR("10.0.0.64/26") // overlaps used1, should coalesce
+
+	got, err := FindAvailableSubnet(parent, []*net.IPNet{used1, used2}, 26)
+	if err != nil {
+		t.Fatalf("FindAvailableSubnet returned error: %v", err)
+	}
+	if want := "10.0.0.128/26"; got.String() != want {
+		t.Errorf("FindAvailableSubnet = %s, want %s", got, want)
+	}
+}
+
+func TestFindAvailableSubnetIPv6(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("2001:db8::/32")
+	_, used, _ := net.ParseCIDR("2001:db8::/48")
+
+	got, err := FindAvailableSubnet(parent, []*net.IPNet{used}, 48)
+	if err != nil {
+		t.Fatalf("FindAvailableSubnet returned error: %v", err)
+	}
+	if want := "2001:db8:1::/48"; got.String() != want {
+		t.Errorf("FindAvailableSubnet = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package ipx
+
+import (
+	"errors"
+	"net"
+)
+
+// Exclude returns the minimal set of CIDR blocks that together cover
+// parent \ remove. If remove is not a subnet of parent, parent is returned
+// unchanged. If parent and remove are equal, the result is empty.
+func Exclude(parent, remove *net.IPNet) ([]*net.IPNet, error) {
+	if (parent.IP.To4() != nil) != (remove.IP.To4() != nil) {
+		return nil, errors.New("parent and remove must be the same address family")
+	}
+	if !IsSubnet(parent, remove) {
+		return []*net.IPNet{parent}, nil
+	}
+	return exclude(parent, remove), nil
+}
+
+// ExcludeMany applies Exclude for each entry in removes in turn, feeding the
+// output of each step in as the parents for the next.
+func ExcludeMany(parent *net.IPNet, removes []*net.IPNet) []*net.IPNet {
+	remaining := []*net.IPNet{parent}
+	for _, remove := range removes {
+		var next []*net.IPNet
+		for _, p := range remaining {
+			pieces, err := Exclude(p, remove)
+			if err != nil {
+				// different address family, remove can't apply to this piece
+				pieces = []*net.IPNet{p}
+			}
+			next = append(next, pieces...)
+		}
+		remaining = next
+	}
+	return remaining
+}
+
+func exclude(parent, remove *net.IPNet) []*net.IPNet {
+	if cidrEqual(parent, remove) {
+		return nil
+	}
+
+	// Split parent into its lower and upper half; recurse into whichever
+	// half contains remove, and keep the other half whole.
+	lower, _ := Subnet(parent, 1, 0)
+	upper, _ := Subnet(parent, 1, 1)
+
+	if IsSubnet(lower, remove) {
+		return append([]*net.IPNet{upper}, exclude(lower, remove)...)
+	}
+	return append([]*net.IPNet{lower}, exclude(upper, remove)...)
+}
+
+func cidrEqual(a, b *net.IPNet) bool {
+	return a.IP.Equal(b.IP) && a.Mask.String() == b.Mask.String()
+}
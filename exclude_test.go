@@ -0,0 +1,87 @@
+package ipx
+
+import (
+	"net"
+	"sort"
+	"testing"
+)
+
+func TestExclude(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("10.0.0.0/24")
+	_, remove, _ := net.ParseCIDR("10.0.0.64/27")
+
+	got, err := Exclude(parent, remove)
+	if err != nil {
+		t.Fatalf("Exclude returned error: %v", err)
+	}
+
+	want := []string{
+		"10.0.0.0/26",
+		"10.0.0.96/27",
+		"10.0.0.128/25",
+	}
+	gotStrs := make([]string, len(got))
+	for i, n := range got {
+		gotStrs[i] = n.String()
+	}
+	sort.Strings(gotStrs)
+	sort.Strings(want)
+	if len(gotStrs) != len(want) {
+		t.Fatalf("Exclude(%s, %s) = %v, want %v", parent, remove, gotStrs, want)
+	}
+	for i := range want {
+		if gotStrs[i] != want[i] {
+			t.Errorf("Exclude(%s, %s) = %v, want %v", parent, remove, gotStrs, want)
+			break
+		}
+	}
+}
+
+func TestExcludeEqual(t *testing.T) {
+	_, n, _ := net.ParseCIDR("10.0.0.0/24")
+	got, err := Exclude(n, n)
+	if err != nil {
+		t.Fatalf("Exclude returned error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Exclude(n, n) = %v, want empty", got)
+	}
+}
+
+func TestExcludeNotSubnet(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("10.0.0.0/24")
+	_, remove, _ := net.ParseCIDR("10.0.1.0/24")
+
+	got, err := Exclude(parent, remove)
+	if err != nil {
+		t.Fatalf("Exclude returned error: %v", err)
+	}
+	if len(got) != 1 || got[0].String() != parent.String() {
+		t.Errorf("Exclude with a non-subnet remove = %v, want [%s]", got, parent)
+	}
+}
+
+func TestExcludeFamilyMismatch(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("10.0.0.0/24")
+	_, remove, _ := net.ParseCIDR("2001:db8::/64")
+
+	if _, err := Exclude(parent, remove); err == nil {
+		t.Error("expected error when parent and remove are different address families")
+	}
+}
+
+func TestExcludeMany(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("10.0.0.0/24")
+	_, r1, _ := net.ParseCIDR("10.0.0.0/26")
+	_, r2, _ := net.ParseCIDR("10.0.0.128/26")
+
+	got := ExcludeMany(parent, []*net.IPNet{r1, r2})
+
+	var total int64
+	for _, n := range got {
+		total += CountHosts(n).Int64()
+	}
+	if want := CountHosts(parent).Int64() - CountHosts(r1).Int64() - CountHosts(r2).Int64(); total != want {
+		t.Errorf("ExcludeMany left %d addresses covered, want %d", total, want)
+	}
+}
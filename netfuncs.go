@@ -2,6 +2,8 @@ package ipx
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 	"net"
 )
 
@@ -26,11 +28,11 @@ func Supernet(ipN *net.IPNet, newPrefix int) *net.IPNet {
 	ip := to128(ipN.IP)
 
 	mask := uint128{0, 1}
-	mask.Lsh(uint(newPrefix))
-	mask.Minus(uint128{0, 1})
-	mask.Lsh(uint(bits - newPrefix))
+	mask = mask.Lsh(uint(newPrefix))
+	mask = mask.Minus(uint128{0, 1})
+	mask = mask.Lsh(uint(bits - newPrefix))
 
-	ip.And(mask)
+	ip = ip.And(mask)
 	from128(ip, out.IP)
 
 	return &out
@@ -56,9 +58,9 @@ func Broadcast(a *net.IPNet) net.IP {
 	ip := to128(a.IP)
 
 	hostMask := uint128{0, 1}
-	hostMask.Lsh(uint(bits - ones))
-	hostMask.Minus(uint128{0, 1})
-	ip.Or(hostMask)
+	hostMask = hostMask.Lsh(uint(bits - ones))
+	hostMask = hostMask.Minus(uint128{0, 1})
+	ip = ip.Or(hostMask)
 
 	from128(ip, out)
 
@@ -79,4 +81,147 @@ func maskPrefix(a, b net.IPMask) bool {
 	aOnes, aBits := a.Size()
 	bOnes, bBits := b.Size()
 	return aBits == bBits && aOnes <= bOnes
-}
\ No newline at end of file
+}
+
+// Subnet returns the num-th subnet of base after extending its prefix by
+// newBits. For example, extending 10.3.0.0/16 by 8 bits and asking for
+// subnet 5 yields 10.3.5.0/24.
+func Subnet(base *net.IPNet, newBits, num int) (*net.IPNet, error) {
+	ones, bits := base.Mask.Size()
+	newPrefix := ones + newBits
+	if newBits < 0 || newPrefix > bits {
+		return nil, fmt.Errorf("not enough address space to extend /%d by %d bits", ones, newBits)
+	}
+	if num < 0 || (newBits < 63 && num >= 1<<uint(newBits)) {
+		return nil, fmt.Errorf("subnet number %d out of range for %d new bits", num, newBits)
+	}
+
+	out := &net.IPNet{IP: make(net.IP, len(base.IP)), Mask: net.CIDRMask(newPrefix, bits)}
+
+	if base.IP.To4() != nil {
+		addr := to32(base.IP) | uint32(num)<<uint(32-newPrefix)
+		from32(addr, out.IP)
+		return out, nil
+	}
+
+	addr := to128(base.IP).Or(uint128{0, uint64(num)}.Lsh(uint(128 - newPrefix)))
+	from128(addr, out.IP)
+	return out, nil
+}
+
+var maxUint64 = new(big.Int).SetUint64(^uint64(0))
+
+// SubnetBig is like Subnet but takes an arbitrary-size subnet index, for
+// walking IPv6 address space too large to fit in an int.
+func SubnetBig(base *net.IPNet, newBits int, num *big.Int) (*net.IPNet, error) {
+	if base.IP.To4() != nil {
+		return nil, errors.New("SubnetBig only supports IPv6 networks, use Subnet instead")
+	}
+
+	ones, bits := base.Mask.Size()
+	newPrefix := ones + newBits
+	if newBits < 0 || newPrefix > bits {
+		return nil, fmt.Errorf("not enough address space to extend /%d by %d bits", ones, newBits)
+	}
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(newBits))
+	if num.Sign() < 0 || num.Cmp(limit) >= 0 {
+		return nil, fmt.Errorf("subnet number %s out of range for %d new bits", num, newBits)
+	}
+
+	hi := new(big.Int).Rsh(num, 64)
+	lo := new(big.Int).And(num, maxUint64)
+	idx := uint128{hi.Uint64(), lo.Uint64()}
+
+	out := &net.IPNet{IP: make(net.IP, 16), Mask: net.CIDRMask(newPrefix, bits)}
+	addr := to128(base.IP).Or(idx.Lsh(uint(128 - newPrefix)))
+	from128(addr, out.IP)
+	return out, nil
+}
+
+// Host returns the num-th host address inside base. A negative num counts
+// from the end of the range, so Host(base, -1) equals Broadcast(base).
+func Host(base *net.IPNet, num int) (net.IP, error) {
+	ones, bits := base.Mask.Size()
+	hostBits := uint(bits - ones)
+
+	if base.IP.To4() != nil {
+		max := uint32(1) << hostBits // overflows to 0 when hostBits == 32, meaning "all of uint32"
+		n := num
+		if n < 0 {
+			n += int(1 << hostBits)
+		}
+		if n < 0 || (hostBits < 32 && uint32(n) >= max) {
+			return nil, fmt.Errorf("host number %d out of range for /%d", num, ones)
+		}
+		out := make(net.IP, 4)
+		from32(to32(base.IP)+uint32(n), out)
+		return out, nil
+	}
+
+	if hostBits > 62 {
+		return nil, fmt.Errorf("host number does not fit in an int for /%d, address space is too large", ones)
+	}
+	max := int64(1) << hostBits
+	n := int64(num)
+	if n < 0 {
+		n += max
+	}
+	if n < 0 || n >= max {
+		return nil, fmt.Errorf("host number %d out of range for /%d", num, ones)
+	}
+	out := make(net.IP, 16)
+	from128(to128(base.IP).Plus(uint128{0, uint64(n)}), out)
+	return out, nil
+}
+
+// mask32 returns the newPrefix-bit netmask for an IPv4 address.
+func mask32(newPrefix int) uint32 {
+	return ^uint32(0) << uint(32-newPrefix)
+}
+
+// mask128 returns the newPrefix-bit netmask for an IPv6 address.
+func mask128(newPrefix int) uint128 {
+	return uint128{0, 1}.Lsh(uint(128 - newPrefix)).Minus(uint128{0, 1}).Not()
+}
+
+// PreviousSubnet returns the same-size network immediately preceding base,
+// after re-masking it to prefixLen. The returned bool reports whether
+// walking backward rolled over past the start of the address space.
+func PreviousSubnet(base *net.IPNet, prefixLen int) (*net.IPNet, bool) {
+	_, bits := base.Mask.Size()
+
+	if base.IP.To4() != nil {
+		addr := to32(base.IP)
+		rollover := addr == 0
+		out := &net.IPNet{IP: make(net.IP, 4), Mask: net.CIDRMask(prefixLen, bits)}
+		from32((addr-1)&mask32(prefixLen), out.IP)
+		return out, rollover
+	}
+
+	addr := to128(base.IP)
+	rollover := addr.Cmp(uint128{0, 0}) == 0
+	out := &net.IPNet{IP: make(net.IP, 16), Mask: net.CIDRMask(prefixLen, bits)}
+	from128(addr.Minus(uint128{0, 1}).And(mask128(prefixLen)), out.IP)
+	return out, rollover
+}
+
+// NextSubnet returns the same-size network immediately following base, after
+// re-masking it to prefixLen. The returned bool reports whether walking
+// forward rolled over past the end of the address space.
+func NextSubnet(base *net.IPNet, prefixLen int) (*net.IPNet, bool) {
+	_, bits := base.Mask.Size()
+
+	if base.IP.To4() != nil {
+		addr := to32(Broadcast(base))
+		rollover := addr == 0xffffffff
+		out := &net.IPNet{IP: make(net.IP, 4), Mask: net.CIDRMask(prefixLen, bits)}
+		from32((addr+1)&mask32(prefixLen), out.IP)
+		return out, rollover
+	}
+
+	addr := to128(Broadcast(base))
+	rollover := addr.Cmp(uint128{0xffffffffffffffff, 0xffffffffffffffff}) == 0
+	out := &net.IPNet{IP: make(net.IP, 16), Mask: net.CIDRMask(prefixLen, bits)}
+	from128(addr.Plus(uint128{0, 1}).And(mask128(prefixLen)), out.IP)
+	return out, rollover
+}
@@ -0,0 +1,236 @@
+package ipx
+
+import (
+	"math/big"
+	"net"
+	"testing"
+)
+
+func TestSubnet(t *testing.T) {
+	_, base, _ := net.ParseCIDR("10.3.0.0/16")
+
+	tests := []struct {
+		newBits, num int
+		want         string
+		wantErr      bool
+	}{
+		{8, 5, "10.3.5.0/24", false},
+		{8, 0, "10.3.0.0/24", false},
+		{8, 256, "", true}, // out of range for 8 new bits
+		{17, 0, "", true},  // not enough address space (would exceed /32)
+	}
+	for _, tt := range tests {
+		got, err := Subnet(base, tt.newBits, tt.num)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Subnet(newBits=%d, num=%d) = %v, want error", tt.newBits, tt.num, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Subnet(newBits=%d, num=%d) returned error: %v", tt.newBits, tt.num, err)
+		}
+		if got.String() != tt.want {
+			t.Errorf("Subnet(newBits=%d, num=%d) = %s, want %s", tt.newBits, tt.num, got, tt.want)
+		}
+	}
+}
+
+func TestSubnetV6(t *testing.T) {
+	_, base, _ := net.ParseCIDR("2001:db8::/32")
+
+	tests := []struct {
+		newBits, num int
+		want         string
+		wantErr      bool
+	}{
+		{16, 5, "2001:db8:5::/48", false},
+		{16, 0, "2001:db8::/48", false},
+		{4, 16, "", true}, // out of range for 4 new bits
+		{97, 0, "", true}, // not enough address space (would exceed /128)
+	}
+	for _, tt := range tests {
+		got, err := Subnet(base, tt.newBits, tt.num)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Subnet(newBits=%d, num=%d) = %v, want error", tt.newBits, tt.num, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Subnet(newBits=%d, num=%d) returned error: %v", tt.newBits, tt.num, err)
+		}
+		if got.String() != tt.want {
+			t.Errorf("Subnet(newBits=%d, num=%d) = %s, want %s", tt.newBits, tt.num, got, tt.want)
+		}
+	}
+}
+
+func TestSubnetBig(t *testing.T) {
+	_, base, _ := net.ParseCIDR("2001:db8::/32")
+
+	// num spans more than 64 bits, exercising the hi/lo split in SubnetBig.
+	num := new(big.Int).Lsh(big.NewInt(1), 64)
+	num.Add(num, big.NewInt(5))
+
+	got, err := SubnetBig(base, 96, num)
+	if err != nil {
+		t.Fatalf("SubnetBig returned error: %v", err)
+	}
+	if want := "2001:db8:0:1::5/128"; got.String() != want {
+		t.Errorf("SubnetBig = %s, want %s", got, want)
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), 96)
+	if _, err := SubnetBig(base, 96, limit); err == nil {
+		t.Error("expected error when num is out of range for newBits")
+	}
+	if _, err := SubnetBig(base, 96, big.NewInt(-1)); err == nil {
+		t.Error("expected error for a negative num")
+	}
+
+	_, v4Base, _ := net.ParseCIDR("10.0.0.0/16")
+	if _, err := SubnetBig(v4Base, 8, big.NewInt(0)); err == nil {
+		t.Error("expected error for an IPv4 base")
+	}
+}
+
+func TestHostV6(t *testing.T) {
+	_, base, _ := net.ParseCIDR("2001:db8::/120")
+
+	tests := []struct {
+		num     int
+		want    string
+		wantErr bool
+	}{
+		{0, "2001:db8::", false},
+		{5, "2001:db8::5", false},
+		{-1, "2001:db8::ff", false}, // equals Broadcast(base)
+		{256, "", true},
+	}
+	for _, tt := range tests {
+		got, err := Host(base, tt.num)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Host(%d) = %v, want error", tt.num, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Host(%d) returned error: %v", tt.num, err)
+		}
+		if !got.Equal(net.ParseIP(tt.want)) {
+			t.Errorf("Host(%d) = %s, want %s", tt.num, got, tt.want)
+		}
+	}
+
+	if bcast := Broadcast(base); !bcast.Equal(net.ParseIP("2001:db8::ff")) {
+		t.Fatalf("sanity check failed: Broadcast(base) = %s", bcast)
+	}
+}
+
+func TestHostV6TooWide(t *testing.T) {
+	_, base, _ := net.ParseCIDR("::/1")
+
+	if _, err := Host(base, 0); err == nil {
+		t.Error("expected error when the host space does not fit in an int")
+	}
+}
+
+func TestHost(t *testing.T) {
+	_, base, _ := net.ParseCIDR("10.3.5.0/24")
+
+	tests := []struct {
+		num     int
+		want    string
+		wantErr bool
+	}{
+		{0, "10.3.5.0", false},
+		{5, "10.3.5.5", false},
+		{-1, "10.3.5.255", false}, // equals Broadcast(base)
+		{256, "", true},
+	}
+	for _, tt := range tests {
+		got, err := Host(base, tt.num)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Host(%d) = %v, want error", tt.num, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Host(%d) returned error: %v", tt.num, err)
+		}
+		if !got.Equal(net.ParseIP(tt.want)) {
+			t.Errorf("Host(%d) = %s, want %s", tt.num, got, tt.want)
+		}
+	}
+
+	if bcast := Broadcast(base); !bcast.Equal(net.ParseIP("10.3.5.255")) {
+		t.Fatalf("sanity check failed: Broadcast(base) = %s", bcast)
+	}
+}
+
+func TestPreviousNextSubnet(t *testing.T) {
+	_, base, _ := net.ParseCIDR("10.3.5.0/24")
+
+	prev, rollover := PreviousSubnet(base, 24)
+	if rollover {
+		t.Error("PreviousSubnet reported rollover unexpectedly")
+	}
+	if prev.String() != "10.3.4.0/24" {
+		t.Errorf("PreviousSubnet = %s, want 10.3.4.0/24", prev)
+	}
+
+	next, rollover := NextSubnet(base, 24)
+	if rollover {
+		t.Error("NextSubnet reported rollover unexpectedly")
+	}
+	if next.String() != "10.3.6.0/24" {
+		t.Errorf("NextSubnet = %s, want 10.3.6.0/24", next)
+	}
+}
+
+func TestNextSubnetRollover(t *testing.T) {
+	_, base, _ := net.ParseCIDR("255.255.255.0/24")
+
+	next, rollover := NextSubnet(base, 24)
+	if !rollover {
+		t.Error("expected NextSubnet to report rollover at the top of the address space")
+	}
+	if next.String() != "0.0.0.0/24" {
+		t.Errorf("NextSubnet rollover = %s, want 0.0.0.0/24", next)
+	}
+}
+
+func TestPreviousNextSubnetV6(t *testing.T) {
+	_, base, _ := net.ParseCIDR("2001:db8:5::/48")
+
+	prev, rollover := PreviousSubnet(base, 48)
+	if rollover {
+		t.Error("PreviousSubnet reported rollover unexpectedly")
+	}
+	if prev.String() != "2001:db8:4::/48" {
+		t.Errorf("PreviousSubnet = %s, want 2001:db8:4::/48", prev)
+	}
+
+	next, rollover := NextSubnet(base, 48)
+	if rollover {
+		t.Error("NextSubnet reported rollover unexpectedly")
+	}
+	if next.String() != "2001:db8:6::/48" {
+		t.Errorf("NextSubnet = %s, want 2001:db8:6::/48", next)
+	}
+}
+
+func TestNextSubnetRolloverV6(t *testing.T) {
+	_, base, _ := net.ParseCIDR("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ff00/120")
+
+	next, rollover := NextSubnet(base, 120)
+	if !rollover {
+		t.Error("expected NextSubnet to report rollover at the top of the address space")
+	}
+	if next.String() != "::/120" {
+		t.Errorf("NextSubnet rollover = %s, want ::/120", next)
+	}
+}
@@ -0,0 +1,133 @@
+package ipx
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+	"net"
+)
+
+// CIDRToRange returns the inclusive start and end addresses covered by n.
+func CIDRToRange(n *net.IPNet) (start, end net.IP) {
+	start = make(net.IP, len(n.IP))
+	copy(start, n.IP)
+	return start, Broadcast(n)
+}
+
+// CountHosts returns the number of addresses covered by n: 2^(bits-prefix),
+// or 1 for a host route.
+func CountHosts(n *net.IPNet) *big.Int {
+	ones, width := n.Mask.Size()
+	if ones == width {
+		return big.NewInt(1)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(width-ones))
+}
+
+// RangeToCIDRs returns the shortest list of CIDR blocks that together cover
+// the inclusive range [start, end]. start and end must be the same address
+// family and start must not be greater than end.
+func RangeToCIDRs(start, end net.IP) ([]*net.IPNet, error) {
+	s4, e4 := start.To4(), end.To4()
+	if (s4 == nil) != (e4 == nil) {
+		return nil, errors.New("start and end must be the same address family")
+	}
+	if s4 != nil {
+		return rangeToCIDRs4(to32(s4), to32(e4))
+	}
+	return rangeToCIDRs6(to128(start), to128(end))
+}
+
+func rangeToCIDRs4(start32, end32 uint32) ([]*net.IPNet, error) {
+	if start32 > end32 {
+		return nil, errors.New("range start must not be greater than end")
+	}
+
+	// Walk using uint64 so that the last block (which may reach all the way
+	// to 255.255.255.255) can be detected without wrapping a uint32 to 0.
+	start, end := uint64(start32), uint64(end32)
+
+	var result []*net.IPNet
+	for start <= end {
+		alignBits := bits.TrailingZeros64(start)
+		if alignBits > 32 {
+			alignBits = 32
+		}
+		spanBits := bits.Len64(end-start+1) - 1
+		k := alignBits
+		if spanBits < k {
+			k = spanBits
+		}
+
+		out := &net.IPNet{IP: make(net.IP, 4), Mask: net.CIDRMask(32-k, 32)}
+		from32(uint32(start), out.IP)
+		result = append(result, out)
+
+		start += 1 << uint(k)
+	}
+	return result, nil
+}
+
+func rangeToCIDRs6(start, end uint128) ([]*net.IPNet, error) {
+	if start.Cmp(end) == 1 {
+		return nil, errors.New("range start must not be greater than end")
+	}
+
+	var result []*net.IPNet
+	for {
+		alignBits := trailingZeros128(start)
+
+		// span = end - start + 1, the number of addresses left to cover. This
+		// overflows uint128 exactly when start is 0 and end is the highest
+		// possible address, i.e. the whole address space remains; treat that
+		// as an unbounded span rather than computing its (undefined) bit length.
+		span := end.Minus(start).Plus(uint128{0, 1})
+		k := alignBits
+		if span.Cmp(uint128{0, 0}) != 0 {
+			if spanBits := bitLen128(span) - 1; spanBits < k {
+				k = spanBits
+			}
+		}
+
+		out := &net.IPNet{IP: make(net.IP, 16), Mask: net.CIDRMask(128-k, 128)}
+		from128(start, out.IP)
+		result = append(result, out)
+
+		if k == 128 {
+			break
+		}
+		// next wraps to zero when this block ends at the highest address.
+		next := start.Plus(uint128{0, 1}.Lsh(uint(k)))
+		if next.Cmp(start) != 1 || next.Cmp(end) == 1 {
+			break
+		}
+		start = next
+	}
+	return result, nil
+}
+
+// trailingZeros128 returns the number of trailing zero bits in v, or 128 if
+// v is zero.
+func trailingZeros128(v uint128) int {
+	zero := uint128{0, 0}
+	if v.Cmp(zero) == 0 {
+		return 128
+	}
+	for i := 0; i < 128; i++ {
+		if v.And(uint128{0, 1}.Lsh(uint(i))).Cmp(zero) != 0 {
+			return i
+		}
+	}
+	return 128
+}
+
+// bitLen128 returns the minimum number of bits required to represent v.
+func bitLen128(v uint128) int {
+	zero := uint128{0, 0}
+	for pos := 0; pos < 128; pos++ {
+		if v.And(uint128{0, 1}.Lsh(uint(127-pos))).Cmp(zero) != 0 {
+			return 128 - pos
+		}
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package ipx
+
+import (
+	"math/big"
+	"net"
+	"testing"
+)
+
+func TestRangeToCIDRs(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end string
+		want       []string
+	}{
+		{
+			name:  "aligned v4 /24",
+			start: "10.0.0.0",
+			end:   "10.0.0.255",
+			want:  []string{"10.0.0.0/24"},
+		},
+		{
+			name:  "unaligned v4 range",
+			start: "10.0.0.1",
+			end:   "10.0.0.5",
+			want:  []string{"10.0.0.1/32", "10.0.0.2/31", "10.0.0.4/31"},
+		},
+		{
+			name:  "aligned v6 /112",
+			start: "2001:db8::1:0",
+			end:   "2001:db8::1:ffff",
+			want:  []string{"2001:db8::1:0/112"},
+		},
+		{
+			name:  "unaligned v6 range",
+			start: "2001:db8::1",
+			end:   "2001:db8::3",
+			want:  []string{"2001:db8::1/128", "2001:db8::2/127"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RangeToCIDRs(net.ParseIP(tt.start), net.ParseIP(tt.end))
+			if err != nil {
+				t.Fatalf("RangeToCIDRs(%s, %s) returned error: %v", tt.start, tt.end, err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("RangeToCIDRs(%s, %s) = %v, want %v", tt.start, tt.end, got, tt.want)
+			}
+			for i, n := range got {
+				if n.String() != tt.want[i] {
+					t.Errorf("RangeToCIDRs(%s, %s)[%d] = %s, want %s", tt.start, tt.end, i, n, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRangeToCIDRsInvalid(t *testing.T) {
+	if _, err := RangeToCIDRs(net.ParseIP("10.0.0.5"), net.ParseIP("10.0.0.1")); err == nil {
+		t.Error("expected error when start > end")
+	}
+	if _, err := RangeToCIDRs(net.ParseIP("10.0.0.1"), net.ParseIP("2001:db8::1")); err == nil {
+		t.Error("expected error when start and end are different address families")
+	}
+}
+
+func TestCIDRToRange(t *testing.T) {
+	_, n, _ := net.ParseCIDR("10.0.0.0/24")
+	start, end := CIDRToRange(n)
+	if !start.Equal(net.ParseIP("10.0.0.0")) {
+		t.Errorf("start = %s, want 10.0.0.0", start)
+	}
+	if !end.Equal(net.ParseIP("10.0.0.255")) {
+		t.Errorf("end = %s, want 10.0.0.255", end)
+	}
+}
+
+func TestCountHosts(t *testing.T) {
+	tests := []struct {
+		cidr string
+		want int64
+	}{
+		{"10.0.0.0/24", 256},
+		{"10.0.0.0/32", 1},
+		{"2001:db8::/112", 65536},
+	}
+	for _, tt := range tests {
+		_, n, _ := net.ParseCIDR(tt.cidr)
+		if got := CountHosts(n); got.Cmp(new(big.Int).SetInt64(tt.want)) != 0 {
+			t.Errorf("CountHosts(%s) = %s, want %d", tt.cidr, got, tt.want)
+		}
+	}
+}
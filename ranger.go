@@ -0,0 +1,425 @@
+package ipx
+
+import "net"
+
+// Ranger answers longest-prefix-match and containment queries over a set of
+// CIDR blocks. It is backed by a path-compressed binary trie keyed on the
+// bits of the network number, so lookups cost O(address width) rather than
+// O(number of stored networks). The zero value is an empty Ranger ready for
+// use, the same as NewRanger returns.
+type Ranger struct {
+	root4 *trieNode4
+	root6 *trieNode6
+}
+
+// NewRanger returns an empty Ranger ready for use.
+func NewRanger() *Ranger {
+	return &Ranger{}
+}
+
+// Insert adds n to the Ranger. Inserting a network that is already present
+// replaces its stored value.
+func (r *Ranger) Insert(n *net.IPNet) {
+	ones, _ := n.Mask.Size()
+	if n.IP.To4() != nil {
+		r.root4 = insert4(r.root4, to32(n.IP), uint8(ones), n)
+		return
+	}
+	r.root6 = insert6(r.root6, to128(n.IP), uint8(ones), n)
+}
+
+// Remove deletes n from the Ranger, reporting whether it was present.
+func (r *Ranger) Remove(n *net.IPNet) bool {
+	ones, _ := n.Mask.Size()
+	if n.IP.To4() != nil {
+		var removed bool
+		r.root4, removed = remove4(r.root4, to32(n.IP), uint8(ones))
+		return removed
+	}
+	var removed bool
+	r.root6, removed = remove6(r.root6, to128(n.IP), uint8(ones))
+	return removed
+}
+
+// Contains reports whether any stored network covers ip.
+func (r *Ranger) Contains(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		return contains4(r.root4, to32(ip4))
+	}
+	return contains6(r.root6, to128(ip))
+}
+
+// ContainingNetworks returns every stored CIDR that covers ip, ordered by
+// increasing prefix length (shortest match first).
+func (r *Ranger) ContainingNetworks(ip net.IP) []*net.IPNet {
+	var result []*net.IPNet
+	if ip4 := ip.To4(); ip4 != nil {
+		containing4(r.root4, to32(ip4), &result)
+		return result
+	}
+	containing6(r.root6, to128(ip), &result)
+	return result
+}
+
+// CoveredNetworks returns every stored CIDR that is equal to or a subnet of n.
+func (r *Ranger) CoveredNetworks(n *net.IPNet) []*net.IPNet {
+	ones, _ := n.Mask.Size()
+	var result []*net.IPNet
+	if n.IP.To4() != nil {
+		covered4(r.root4, to32(n.IP), uint8(ones), &result)
+		return result
+	}
+	covered6(r.root6, to128(n.IP), uint8(ones), &result)
+	return result
+}
+
+// trieNode4 is a node in a path-compressed binary trie over 32-bit network
+// numbers. addr holds a key whose leading bits are the node's path; prefix is
+// the number of bits of addr that are significant from the root down to this
+// node, and the bits after them are not masked off. net is non-nil when a
+// stored network terminates exactly here.
+type trieNode4 struct {
+	addr   uint32
+	prefix uint8
+	net    *net.IPNet
+	left   *trieNode4
+	right  *trieNode4
+}
+
+func bitAt32(addr uint32, pos uint8) int {
+	return int((addr >> (31 - pos)) & 1)
+}
+
+func commonPrefixLen32(a, b uint32) uint8 {
+	x := a ^ b
+	var n uint8
+	for n < 32 && (x&(1<<(31-n))) == 0 {
+		n++
+	}
+	return n
+}
+
+func insert4(n *trieNode4, addr uint32, prefix uint8, ipn *net.IPNet) *trieNode4 {
+	if n == nil {
+		return &trieNode4{addr: addr, prefix: prefix, net: ipn}
+	}
+
+	cpl := commonPrefixLen32(addr, n.addr)
+	if cpl > prefix {
+		cpl = prefix
+	}
+	if cpl > n.prefix {
+		cpl = n.prefix
+	}
+
+	switch {
+	case cpl == prefix && cpl == n.prefix:
+		n.net = ipn
+		return n
+	case cpl == n.prefix:
+		if bitAt32(addr, cpl) == 0 {
+			n.left = insert4(n.left, addr, prefix, ipn)
+		} else {
+			n.right = insert4(n.right, addr, prefix, ipn)
+		}
+		return n
+	default:
+		branch := &trieNode4{addr: addr, prefix: cpl}
+		var leaf *trieNode4
+		if cpl == prefix {
+			branch.net = ipn
+		} else {
+			leaf = &trieNode4{addr: addr, prefix: prefix, net: ipn}
+		}
+		if bitAt32(n.addr, cpl) == 0 {
+			branch.left, branch.right = n, leaf
+		} else {
+			branch.left, branch.right = leaf, n
+		}
+		return branch
+	}
+}
+
+func pruneNode4(n *trieNode4) *trieNode4 {
+	if n == nil || n.net != nil {
+		return n
+	}
+	switch {
+	case n.left == nil:
+		return n.right
+	case n.right == nil:
+		return n.left
+	default:
+		return n
+	}
+}
+
+func remove4(n *trieNode4, addr uint32, prefix uint8) (*trieNode4, bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	cpl := commonPrefixLen32(addr, n.addr)
+	switch {
+	case n.prefix == prefix:
+		if cpl < prefix || n.net == nil {
+			return n, false
+		}
+		n.net = nil
+		return pruneNode4(n), true
+	case n.prefix > prefix || cpl < n.prefix:
+		return n, false
+	default:
+		var removed bool
+		if bitAt32(addr, n.prefix) == 0 {
+			n.left, removed = remove4(n.left, addr, prefix)
+		} else {
+			n.right, removed = remove4(n.right, addr, prefix)
+		}
+		return pruneNode4(n), removed
+	}
+}
+
+func contains4(n *trieNode4, addr uint32) bool {
+	for n != nil {
+		if commonPrefixLen32(addr, n.addr) < n.prefix {
+			return false
+		}
+		if n.net != nil {
+			return true
+		}
+		if bitAt32(addr, n.prefix) == 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return false
+}
+
+func containing4(n *trieNode4, addr uint32, result *[]*net.IPNet) {
+	for n != nil {
+		if commonPrefixLen32(addr, n.addr) < n.prefix {
+			return
+		}
+		if n.net != nil {
+			*result = append(*result, n.net)
+		}
+		if n.prefix >= 32 {
+			return
+		}
+		if bitAt32(addr, n.prefix) == 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+}
+
+func collectAll4(n *trieNode4, result *[]*net.IPNet) {
+	if n == nil {
+		return
+	}
+	if n.net != nil {
+		*result = append(*result, n.net)
+	}
+	collectAll4(n.left, result)
+	collectAll4(n.right, result)
+}
+
+func covered4(n *trieNode4, addr uint32, prefix uint8, result *[]*net.IPNet) {
+	if n == nil {
+		return
+	}
+	cpl := commonPrefixLen32(addr, n.addr)
+
+	if n.prefix >= prefix {
+		if cpl >= prefix {
+			collectAll4(n, result)
+		}
+		return
+	}
+	if cpl < n.prefix {
+		return
+	}
+	if bitAt32(addr, n.prefix) == 0 {
+		covered4(n.left, addr, prefix, result)
+	} else {
+		covered4(n.right, addr, prefix, result)
+	}
+}
+
+// trieNode6 is the IPv6 counterpart of trieNode4, keyed on 128-bit network
+// numbers via uint128.
+type trieNode6 struct {
+	addr   uint128
+	prefix uint8
+	net    *net.IPNet
+	left   *trieNode6
+	right  *trieNode6
+}
+
+func bitAt128(addr uint128, pos uint8) int {
+	if addr.And(uint128{0, 1}.Lsh(uint(127-pos))).Cmp(uint128{0, 0}) != 0 {
+		return 1
+	}
+	return 0
+}
+
+func commonPrefixLen128(a, b uint128) uint8 {
+	var n uint8
+	for n < 128 && bitAt128(a, n) == bitAt128(b, n) {
+		n++
+	}
+	return n
+}
+
+func insert6(n *trieNode6, addr uint128, prefix uint8, ipn *net.IPNet) *trieNode6 {
+	if n == nil {
+		return &trieNode6{addr: addr, prefix: prefix, net: ipn}
+	}
+
+	cpl := commonPrefixLen128(addr, n.addr)
+	if cpl > prefix {
+		cpl = prefix
+	}
+	if cpl > n.prefix {
+		cpl = n.prefix
+	}
+
+	switch {
+	case cpl == prefix && cpl == n.prefix:
+		n.net = ipn
+		return n
+	case cpl == n.prefix:
+		if bitAt128(addr, cpl) == 0 {
+			n.left = insert6(n.left, addr, prefix, ipn)
+		} else {
+			n.right = insert6(n.right, addr, prefix, ipn)
+		}
+		return n
+	default:
+		branch := &trieNode6{addr: addr, prefix: cpl}
+		var leaf *trieNode6
+		if cpl == prefix {
+			branch.net = ipn
+		} else {
+			leaf = &trieNode6{addr: addr, prefix: prefix, net: ipn}
+		}
+		if bitAt128(n.addr, cpl) == 0 {
+			branch.left, branch.right = n, leaf
+		} else {
+			branch.left, branch.right = leaf, n
+		}
+		return branch
+	}
+}
+
+func pruneNode6(n *trieNode6) *trieNode6 {
+	if n == nil || n.net != nil {
+		return n
+	}
+	switch {
+	case n.left == nil:
+		return n.right
+	case n.right == nil:
+		return n.left
+	default:
+		return n
+	}
+}
+
+func remove6(n *trieNode6, addr uint128, prefix uint8) (*trieNode6, bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	cpl := commonPrefixLen128(addr, n.addr)
+	switch {
+	case n.prefix == prefix:
+		if cpl < prefix || n.net == nil {
+			return n, false
+		}
+		n.net = nil
+		return pruneNode6(n), true
+	case n.prefix > prefix || cpl < n.prefix:
+		return n, false
+	default:
+		var removed bool
+		if bitAt128(addr, n.prefix) == 0 {
+			n.left, removed = remove6(n.left, addr, prefix)
+		} else {
+			n.right, removed = remove6(n.right, addr, prefix)
+		}
+		return pruneNode6(n), removed
+	}
+}
+
+func contains6(n *trieNode6, addr uint128) bool {
+	for n != nil {
+		if commonPrefixLen128(addr, n.addr) < n.prefix {
+			return false
+		}
+		if n.net != nil {
+			return true
+		}
+		if bitAt128(addr, n.prefix) == 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return false
+}
+
+func containing6(n *trieNode6, addr uint128, result *[]*net.IPNet) {
+	for n != nil {
+		if commonPrefixLen128(addr, n.addr) < n.prefix {
+			return
+		}
+		if n.net != nil {
+			*result = append(*result, n.net)
+		}
+		if n.prefix >= 128 {
+			return
+		}
+		if bitAt128(addr, n.prefix) == 0 {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+}
+
+func collectAll6(n *trieNode6, result *[]*net.IPNet) {
+	if n == nil {
+		return
+	}
+	if n.net != nil {
+		*result = append(*result, n.net)
+	}
+	collectAll6(n.left, result)
+	collectAll6(n.right, result)
+}
+
+func covered6(n *trieNode6, addr uint128, prefix uint8, result *[]*net.IPNet) {
+	if n == nil {
+		return
+	}
+	cpl := commonPrefixLen128(addr, n.addr)
+
+	if n.prefix >= prefix {
+		if cpl >= prefix {
+			collectAll6(n, result)
+		}
+		return
+	}
+	if cpl < n.prefix {
+		return
+	}
+	if bitAt128(addr, n.prefix) == 0 {
+		covered6(n.left, addr, prefix, result)
+	} else {
+		covered6(n.right, addr, prefix, result)
+	}
+}
@@ -0,0 +1,87 @@
+package ipx
+
+import (
+	"net"
+	"testing"
+)
+
+func mustCIDR(t *testing.T, s string) *net.IPNet {
+	t.Helper()
+	_, n, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatalf("ParseCIDR(%q): %v", s, err)
+	}
+	return n
+}
+
+func TestRangerContains(t *testing.T) {
+	r := NewRanger()
+	r.Insert(mustCIDR(t, "10.0.0.0/8"))
+	r.Insert(mustCIDR(t, "10.1.0.0/16"))
+	r.Insert(mustCIDR(t, "2001:db8::/32"))
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.1.2.3", true},
+		{"10.2.0.1", true},
+		{"11.0.0.1", false},
+		{"2001:db8::1", true},
+		{"2001:db9::1", false},
+	}
+	for _, tt := range tests {
+		if got := r.Contains(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestRangerContainingNetworks(t *testing.T) {
+	r := NewRanger()
+	r.Insert(mustCIDR(t, "10.0.0.0/8"))
+	r.Insert(mustCIDR(t, "10.1.0.0/16"))
+	r.Insert(mustCIDR(t, "10.1.2.0/24"))
+
+	got := r.ContainingNetworks(net.ParseIP("10.1.2.3"))
+	want := []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24"}
+	if len(got) != len(want) {
+		t.Fatalf("ContainingNetworks returned %d networks, want %d: %v", len(got), len(want), got)
+	}
+	for i, n := range got {
+		if n.String() != want[i] {
+			t.Errorf("ContainingNetworks()[%d] = %s, want %s", i, n.String(), want[i])
+		}
+	}
+}
+
+func TestRangerCoveredNetworks(t *testing.T) {
+	r := NewRanger()
+	r.Insert(mustCIDR(t, "10.1.0.0/24"))
+	r.Insert(mustCIDR(t, "10.1.1.0/24"))
+	r.Insert(mustCIDR(t, "10.2.0.0/24"))
+
+	got := r.CoveredNetworks(mustCIDR(t, "10.1.0.0/16"))
+	if len(got) != 2 {
+		t.Fatalf("CoveredNetworks returned %d networks, want 2: %v", len(got), got)
+	}
+}
+
+func TestRangerRemove(t *testing.T) {
+	r := NewRanger()
+	n := mustCIDR(t, "10.1.0.0/16")
+	r.Insert(n)
+
+	if !r.Contains(net.ParseIP("10.1.2.3")) {
+		t.Fatal("expected Contains to be true before Remove")
+	}
+	if removed := r.Remove(n); !removed {
+		t.Fatal("Remove returned false for a present network")
+	}
+	if r.Contains(net.ParseIP("10.1.2.3")) {
+		t.Fatal("expected Contains to be false after Remove")
+	}
+	if removed := r.Remove(n); removed {
+		t.Fatal("Remove returned true for an absent network")
+	}
+}